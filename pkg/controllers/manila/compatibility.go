@@ -0,0 +1,59 @@
+package manila
+
+import (
+	"fmt"
+	"strings"
+)
+
+// allowedCompatibilitySettings is the allowlist of compatibilitySettings
+// keys this operator understands, mirroring the settings the upstream
+// manila-csi-plugin accepts.
+var allowedCompatibilitySettings = map[string]bool{
+	"cephfs-client-id":    true,
+	"cephfs-mounter":      true,
+	"nfs-shareProto":      true,
+	"appendShareMetadata": true,
+}
+
+// ParseCompatibilitySettings parses a "KEY=VALUE,KEY=VALUE" compatibility
+// settings string and validates every key against
+// allowedCompatibilitySettings.
+func ParseCompatibilitySettings(raw string) (map[string]string, error) {
+	settings := map[string]string{}
+	if raw == "" {
+		return settings, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid compatibility setting %q: expected KEY=VALUE", pair)
+		}
+		settings[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	if err := validateCompatibilitySettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// validateCompatibilitySettings rejects any key not in
+// allowedCompatibilitySettings; see applyObservedConfig for when this runs.
+func validateCompatibilitySettings(settings map[string]string) error {
+	for key := range settings {
+		if !allowedCompatibilitySettings[key] {
+			return fmt.Errorf("unknown compatibility setting %q", key)
+		}
+	}
+	return nil
+}
+
+// SetCompatibilitySettings updates the operator-wide compatibility settings
+// propagated into every generated StorageClass. It is called by
+// applyObservedConfig, which validates settings first.
+func (c *Controller) SetCompatibilitySettings(settings map[string]string) {
+	c.compatibilitySettings = settings
+}
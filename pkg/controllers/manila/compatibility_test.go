@@ -0,0 +1,27 @@
+package manila
+
+import "testing"
+
+func TestParseCompatibilitySettings(t *testing.T) {
+	settings, err := ParseCompatibilitySettings("nfs-shareProto=NFS, cephfs-client-id=operator")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings["nfs-shareProto"] != "NFS" || settings["cephfs-client-id"] != "operator" {
+		t.Fatalf("unexpected settings: %+v", settings)
+	}
+}
+
+func TestParseCompatibilitySettingsRejectsUnknownKey(t *testing.T) {
+	if _, err := ParseCompatibilitySettings("bogus-key=value"); err == nil {
+		t.Fatal("expected an error for an unknown compatibility setting key")
+	}
+}
+
+func TestApplyObservedConfigRejectsUnknownCompatibilityKey(t *testing.T) {
+	c, _ := newTestController(t)
+	err := c.applyObservedConfig([]byte(`{"compatibilitySettings":{"bogus-key":"value"}}`))
+	if err == nil {
+		t.Fatal("expected applyObservedConfig to reject an unknown compatibility setting key")
+	}
+}
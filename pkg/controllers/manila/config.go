@@ -0,0 +1,41 @@
+package manila
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// observedConfig is the shape of the admin-facing knobs this operator reads
+// out of the operator spec's ObservedConfig. There is no dedicated
+// ManilaDriver CRD (yet), so share-type overrides, stale StorageClass
+// pruning and compatibility settings are all configured this way, the same
+// place library-go operators already put unsupported config overrides.
+type observedConfig struct {
+	ShareTypeOverrides    []ShareTypeConfig `json:"shareTypeOverrides,omitempty"`
+	PruneStorageClasses   bool              `json:"pruneStorageClasses,omitempty"`
+	PruneGracePeriod      int               `json:"pruneGracePeriod,omitempty"`
+	CompatibilitySettings map[string]string `json:"compatibilitySettings,omitempty"`
+}
+
+// applyObservedConfig parses raw (opSpec.ObservedConfig.Raw) and applies it
+// to the controller, so SetShareTypeOverrides, SetPruneSettings and
+// SetCompatibilitySettings are driven by the operator spec instead of
+// sitting unused. It is called on every sync, so a config update - including
+// one that makes compatibilitySettings invalid - is caught here instead of
+// surfacing later as a confusing volume-provisioning failure.
+func (c *Controller) applyObservedConfig(raw []byte) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var cfg observedConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("failed to parse observedConfig: %w", err)
+	}
+	if err := validateCompatibilitySettings(cfg.CompatibilitySettings); err != nil {
+		return err
+	}
+	c.SetShareTypeOverrides(cfg.ShareTypeOverrides)
+	c.SetPruneSettings(cfg.PruneStorageClasses, cfg.PruneGracePeriod)
+	c.SetCompatibilitySettings(cfg.CompatibilitySettings)
+	return nil
+}
@@ -7,7 +7,10 @@ import (
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
+	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	configlisters "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/csi-driver-manila-operator/pkg/util"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -21,6 +24,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	storagelisters "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/klog"
+	"k8s.io/utils/pointer"
 )
 
 // This Controller watches OpenStack and:
@@ -30,21 +34,45 @@ import (
 // 2) Creates StorageClass for each share type provided by Manila.
 // 3) If there is no Manila in the OpenStack where the cluster runs,
 //    it marks the operator with condition Disabled=true.
+// 4) If the cluster is not running on OpenStack at all, it marks the
+//    operator with condition Disabled=true and reason NotOpenStack,
+//    without ever talking to an OpenStack API, and starts the CSI
+//    driver controllers once (if ever) the Infrastructure resource
+//    reports OpenStack.
 //
 // Note that the CSI driver(s) are not un-installed when Manila becomes
 // missing or it stops providing shares of given type - Manila bight be
 // under (short?) maintenance / reconfiguration.
 // Similarly, StorageClasses are not deleted when a share type disappears
 // from Manila.
+// With stale StorageClass pruning enabled (see prune.go), they are deleted
+// once the prune grace period has elapsed.
+//
+// AllowVolumeExpansion support is intentionally incomplete: share types can
+// opt in via enableVolumeExpansionExtraSpec, but the external-resizer
+// sidecar and its RBAC are not yet part of the manifests this operator
+// deploys, so ControllerExpandVolume has nothing servicing it. Treat that
+// feature as not done until the sidecar/RBAC wiring lands alongside it.
 type Controller struct {
-	operatorClient     v1helpers.OperatorClient
-	kubeClient         kubernetes.Interface
-	openStackClient    *openStackClient
-	storageClassLister storagelisters.StorageClassLister
+	operatorClient       v1helpers.OperatorClient
+	kubeClient           kubernetes.Interface
+	openStackClient      *openStackClient
+	storageClassLister   storagelisters.StorageClassLister
+	infrastructureLister configlisters.InfrastructureLister
 	// Controllers to start when Manila is detected
 	csiControllers     []Runnable
 	controllersRunning bool
 	eventRecorder      events.Recorder
+	// Per-share-type StorageClass customization, driven by the operator
+	// spec's observedConfig.shareTypeOverrides, see config.go.
+	shareTypeOverrides []ShareTypeConfig
+	// Stale StorageClass garbage collection, see prune.go.
+	pruneEnabled             bool
+	pruneGracePeriod         int
+	missingStorageClassSyncs map[string]int
+	// Operator-wide compatibility settings propagated as extra StorageClass
+	// parameters, see compatibility.go.
+	compatibilitySettings map[string]string
 }
 
 type Runnable interface {
@@ -58,28 +86,50 @@ const (
 	resyncInterval = 1 * time.Minute
 
 	operatorConditionPrefix = "ManilaController"
+
+	// enableVolumeExpansionExtraSpec is a share type extra-spec that lets
+	// admins opt individual share types into ControllerExpandVolume. It
+	// defaults to off: the external-resizer sidecar and its RBAC are not
+	// yet wired into the manifests this operator deploys, so advertising
+	// AllowVolumeExpansion by default would let PVC resize requests be
+	// accepted by the API server and then hang forever with nothing to
+	// service them.
+	enableVolumeExpansionExtraSpec = "manila.csi.openstack.org/enable-volume-expansion"
+
+	// infrastructureName is the name of the cluster-wide Infrastructure
+	// resource that reports the platform the cluster runs on.
+	infrastructureName = "cluster"
+
+	reasonNotOpenStack = "NotOpenStack"
+	reasonNoManila     = "NoManila"
 )
 
 func NewController(
 	operatorClient v1helpers.OperatorClient,
 	kubeClient kubernetes.Interface,
 	informers v1helpers.KubeInformersForNamespaces,
+	configInformers configinformers.SharedInformerFactory,
 	openStackClient *openStackClient,
 	csiControllers []Runnable,
 	eventRecorder events.Recorder) factory.Controller {
 
 	scInformer := informers.InformersFor("").Storage().V1().StorageClasses()
+	infraInformer := configInformers.Config().V1().Infrastructures()
 	c := &Controller{
-		operatorClient:     operatorClient,
-		kubeClient:         kubeClient,
-		storageClassLister: scInformer.Lister(),
-		openStackClient:    openStackClient,
-		csiControllers:     csiControllers,
-		eventRecorder:      eventRecorder,
+		operatorClient:           operatorClient,
+		kubeClient:               kubeClient,
+		storageClassLister:       scInformer.Lister(),
+		infrastructureLister:     infraInformer.Lister(),
+		openStackClient:          openStackClient,
+		csiControllers:           csiControllers,
+		eventRecorder:            eventRecorder,
+		pruneGracePeriod:         defaultPruneGracePeriod,
+		missingStorageClassSyncs: map[string]int{},
 	}
 	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(operatorClient).ResyncEvery(resyncInterval).WithInformers(
 		operatorClient.Informer(),
 		scInformer.Informer(),
+		infraInformer.Informer(),
 	).ToController("ManilaController", eventRecorder)
 }
 
@@ -95,12 +145,25 @@ func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) erro
 		return nil
 	}
 
+	if err := c.applyObservedConfig(opSpec.ObservedConfig.Raw); err != nil {
+		return err
+	}
+
+	isOpenStack, err := c.isOpenStackPlatform()
+	if err != nil {
+		return err
+	}
+	if !isOpenStack {
+		klog.V(4).Infof("Cluster is not running on OpenStack, disabling Manila controllers")
+		return c.setDisabled(reasonNotOpenStack, "The cluster is not running on OpenStack")
+	}
+
 	shareTypes, err := c.openStackClient.GetShareTypes()
 	if err != nil {
 		switch err.(type) {
 		case *gophercloud.ErrEndpointNotFound:
 			// OpenStack does not support manila, report the operator as disabled
-			return c.setDisabled("This OpenStack does not provide Manila service")
+			return c.setDisabled(reasonNoManila, "This OpenStack does not provide Manila service")
 		default:
 			return err
 		}
@@ -108,7 +171,7 @@ func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) erro
 
 	if len(shareTypes) == 0 {
 		klog.V(4).Infof("Manila does not provide any share types")
-		return c.setDisabled("Manila does not provide any share types")
+		return c.setDisabled(reasonNoManila, "Manila does not provide any share types")
 	}
 	// Manila has some shares: start the actual CSI driver controller sets
 	if !c.controllersRunning {
@@ -128,11 +191,27 @@ func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) erro
 
 func (c *Controller) syncStorageClasses(ctx context.Context, shareTypes []sharetypes.ShareType) error {
 	var errs []error
+	expectedNames := map[string]bool{}
 	for _, shareType := range shareTypes {
-		klog.V(4).Infof("Syncing storage class for shareType type %s", shareType.Name)
-		sc := c.generateStorageClass(shareType)
-		err := c.applyStorageClass(ctx, sc)
-		if err != nil {
+		for _, override := range c.shareTypeOverridesFor(shareType.Name) {
+			if override.Exclude {
+				klog.V(4).Infof("Share type %s is excluded, deleting its StorageClass if any", shareType.Name)
+				if err := c.deleteStorageClass(ctx, storageClassName(shareType.Name, override)); err != nil {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			name := storageClassName(shareType.Name, override)
+			expectedNames[name] = true
+			klog.V(4).Infof("Syncing storage class %s for share type %s", name, shareType.Name)
+			sc := c.generateStorageClass(shareType, override)
+			if err := c.applyStorageClass(ctx, sc); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if c.pruneEnabled {
+		if err := c.pruneStaleStorageClasses(ctx, expectedNames); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -142,13 +221,22 @@ func (c *Controller) syncStorageClasses(ctx context.Context, shareTypes []sharet
 	return nil
 }
 
+func (c *Controller) deleteStorageClass(ctx context.Context, name string) error {
+	err := c.kubeClient.StorageV1().StorageClasses().Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (c *Controller) applyStorageClass(ctx context.Context, expected *storagev1.StorageClass) error {
 	current, err := c.storageClassLister.Get(expected.Name)
 	if err == nil {
-		if !reflect.DeepEqual(expected.Parameters, current.Parameters) {
-			// StorageClass.Parameters changed. Typically, secret namespace
-			// is different when moving from OLM to non-OLM operator.
-			// Delete the old class and create a new one.
+		if storageClassNeedsRecreate(expected, current) {
+			// Parameters, ReclaimPolicy, VolumeBindingMode and MountOptions
+			// are all immutable once a StorageClass exists, so an in-place
+			// ApplyStorageClass would silently ignore a changed one of
+			// these. Delete the old class and create a new one instead.
 			if err := c.kubeClient.StorageV1().StorageClasses().Delete(ctx, expected.Name, metav1.DeleteOptions{}); err != nil {
 				if apierrors.IsNotFound(err) {
 					err = nil
@@ -167,26 +255,109 @@ func (c *Controller) applyStorageClass(ctx context.Context, expected *storagev1.
 	return err
 }
 
-func (c *Controller) generateStorageClass(shareType sharetypes.ShareType) *storagev1.StorageClass {
-	storageClassName := util.StorageClassNamePrefix + shareType.Name
+// storageClassNeedsRecreate reports whether any of the StorageClass fields
+// that cannot be changed in place differ between expected and current, and
+// the class therefore needs to be deleted and recreated to pick up the
+// change.
+func storageClassNeedsRecreate(expected, current *storagev1.StorageClass) bool {
+	return !reflect.DeepEqual(expected.Parameters, current.Parameters) ||
+		!reflect.DeepEqual(expected.ReclaimPolicy, current.ReclaimPolicy) ||
+		!reflect.DeepEqual(expected.VolumeBindingMode, current.VolumeBindingMode) ||
+		!reflect.DeepEqual(expected.MountOptions, current.MountOptions)
+}
+
+func (c *Controller) generateStorageClass(shareType sharetypes.ShareType, override ShareTypeConfig) *storagev1.StorageClass {
+	parameters := map[string]string{
+		"type": shareType.Name,
+		"csi.storage.k8s.io/provisioner-secret-name":       util.ManilaSecretName,
+		"csi.storage.k8s.io/provisioner-secret-namespace":  util.OperatorNamespace,
+		"csi.storage.k8s.io/node-stage-secret-name":        util.ManilaSecretName,
+		"csi.storage.k8s.io/node-stage-secret-namespace":   util.OperatorNamespace,
+		"csi.storage.k8s.io/node-publish-secret-name":      util.ManilaSecretName,
+		"csi.storage.k8s.io/node-publish-secret-namespace": util.OperatorNamespace,
+	}
+	// Operator-wide compatibility settings apply to every class; per-share-type
+	// overrides take precedence over those, and over the operator's own defaults.
+	for k, v := range c.compatibilitySettings {
+		parameters[k] = v
+	}
+	for k, v := range override.Parameters {
+		parameters[k] = v
+	}
+
 	sc := &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: storageClassName,
-		},
-		Provisioner: "manila.csi.openstack.org",
-		Parameters: map[string]string{
-			"type": shareType.Name,
-			"csi.storage.k8s.io/provisioner-secret-name":       util.ManilaSecretName,
-			"csi.storage.k8s.io/provisioner-secret-namespace":  util.OperatorNamespace,
-			"csi.storage.k8s.io/node-stage-secret-name":        util.ManilaSecretName,
-			"csi.storage.k8s.io/node-stage-secret-namespace":   util.OperatorNamespace,
-			"csi.storage.k8s.io/node-publish-secret-name":      util.ManilaSecretName,
-			"csi.storage.k8s.io/node-publish-secret-namespace": util.OperatorNamespace,
+			Name: storageClassName(shareType.Name, override),
+			Labels: map[string]string{
+				labelManagedBy: labelManagedByValue,
+				labelShareType: shareType.Name,
+			},
 		},
+		Provisioner:       "manila.csi.openstack.org",
+		Parameters:        parameters,
+		ReclaimPolicy:     override.ReclaimPolicy,
+		VolumeBindingMode: override.VolumeBindingMode,
+		MountOptions:      override.MountOptions,
+	}
+	if override.Default {
+		sc.Annotations = map[string]string{
+			"storageclass.kubernetes.io/is-default-class": "true",
+		}
+	}
+	if shareTypeAllowsExpansion(shareType) {
+		sc.AllowVolumeExpansion = pointer.BoolPtr(true)
 	}
 	return sc
 }
 
+// storageClassName computes the name of the StorageClass generated for a
+// share type, honoring the override's NameSuffix so several overrides can
+// target the same share type.
+func storageClassName(shareType string, override ShareTypeConfig) string {
+	return util.StorageClassNamePrefix + shareType + override.NameSuffix
+}
+
+// shareTypeAllowsExpansion returns true only if the share type's extra-specs
+// explicitly enable it via enableVolumeExpansionExtraSpec. This is opt-in,
+// not opt-out: until the external-resizer sidecar and its RBAC are wired
+// into the manifests this operator deploys, ControllerExpandVolume has
+// nothing servicing it, so AllowVolumeExpansion must stay off by default.
+func shareTypeAllowsExpansion(shareType sharetypes.ShareType) bool {
+	enabled, ok := shareType.ExtraSpecs[enableVolumeExpansionExtraSpec]
+	if !ok {
+		return false
+	}
+	switch v := enabled.(type) {
+	case string:
+		return v == "true"
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
+// SetShareTypeOverrides updates the per-share-type StorageClass
+// customization. It is called by applyObservedConfig on every sync, so
+// updates to the operator spec's observedConfig.shareTypeOverrides take
+// effect on the next reconcile.
+func (c *Controller) SetShareTypeOverrides(overrides []ShareTypeConfig) {
+	c.shareTypeOverrides = overrides
+}
+
+// SetPruneSettings configures stale StorageClass garbage collection, see
+// prune.go. gracePeriod is the number of consecutive syncs a StorageClass
+// must stay unexpected before it is deleted; a value <= 0 falls back to
+// defaultPruneGracePeriod. It is called by applyObservedConfig on every
+// sync.
+func (c *Controller) SetPruneSettings(enabled bool, gracePeriod int) {
+	c.pruneEnabled = enabled
+	if gracePeriod <= 0 {
+		gracePeriod = defaultPruneGracePeriod
+	}
+	c.pruneGracePeriod = gracePeriod
+}
+
 func (c *Controller) setEnabled() error {
 	availableCnd := operatorv1.OperatorCondition{
 		Type:   operatorConditionPrefix + operatorv1.OperatorStatusTypeAvailable,
@@ -198,11 +369,11 @@ func (c *Controller) setEnabled() error {
 	return err
 }
 
-func (c *Controller) setDisabled(msg string) error {
+func (c *Controller) setDisabled(reason, msg string) error {
 	disabledCnd := operatorv1.OperatorCondition{
 		Type:    operatorConditionPrefix + "Disabled",
 		Status:  operatorv1.ConditionTrue,
-		Reason:  "NoManila",
+		Reason:  reason,
 		Message: msg,
 	}
 	_, _, err := v1helpers.UpdateStatus(c.operatorClient,
@@ -211,6 +382,22 @@ func (c *Controller) setDisabled(msg string) error {
 	return err
 }
 
+// isOpenStackPlatform reports whether the cluster's Infrastructure resource
+// declares OpenStack as its platform. Clusters that are not on OpenStack
+// never have a Manila to talk to, so the controller should stay quiet
+// instead of repeatedly failing to reach an OpenStack API that doesn't
+// exist.
+func (c *Controller) isOpenStackPlatform() (bool, error) {
+	infra, err := c.infrastructureLister.Get(infrastructureName)
+	if err != nil {
+		return false, err
+	}
+	if infra.Status.PlatformStatus == nil {
+		return false, nil
+	}
+	return infra.Status.PlatformStatus.Type == configv1.OpenStackPlatformType, nil
+}
+
 func removeConditionFn(cnd string) v1helpers.UpdateStatusFunc {
 	return func(oldStatus *operatorv1.OperatorStatus) error {
 		v1helpers.RemoveOperatorCondition(&oldStatus.Conditions, cnd)
@@ -0,0 +1,68 @@
+package manila
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
+	"github.com/openshift/csi-driver-manila-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyStorageClassRecreatesOnReclaimPolicyChange(t *testing.T) {
+	deletePolicy := corev1.PersistentVolumeReclaimPolicy("Delete")
+	retainPolicy := corev1.PersistentVolumeReclaimPolicy("Retain")
+	existing := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: util.StorageClassNamePrefix + "default"},
+		Provisioner:   "manila.csi.openstack.org",
+		Parameters:    map[string]string{"type": "default"},
+		ReclaimPolicy: &deletePolicy,
+	}
+	c, kubeClient := newTestController(t, existing)
+	c.shareTypeOverrides = []ShareTypeConfig{{ShareType: "default", ReclaimPolicy: &retainPolicy}}
+
+	shareType := sharetypes.ShareType{Name: "default"}
+	override := c.shareTypeOverridesFor(shareType.Name)[0]
+	sc := c.generateStorageClass(shareType, override)
+
+	if err := c.applyStorageClass(context.TODO(), sc); err != nil {
+		t.Fatalf("applyStorageClass returned error: %v", err)
+	}
+
+	updated, err := kubeClient.StorageV1().StorageClasses().Get(context.TODO(), existing.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get StorageClass: %v", err)
+	}
+	if updated.ReclaimPolicy == nil || *updated.ReclaimPolicy != retainPolicy {
+		t.Fatalf("expected ReclaimPolicy to be updated to %q, got %v", retainPolicy, updated.ReclaimPolicy)
+	}
+}
+
+func TestApplyStorageClassRecreatesOnMountOptionsChange(t *testing.T) {
+	existing := &storagev1.StorageClass{
+		ObjectMeta:   metav1.ObjectMeta{Name: util.StorageClassNamePrefix + "default"},
+		Provisioner:  "manila.csi.openstack.org",
+		Parameters:   map[string]string{"type": "default"},
+		MountOptions: []string{"ro"},
+	}
+	c, kubeClient := newTestController(t, existing)
+	c.shareTypeOverrides = []ShareTypeConfig{{ShareType: "default", MountOptions: []string{"rw", "hard"}}}
+
+	shareType := sharetypes.ShareType{Name: "default"}
+	override := c.shareTypeOverridesFor(shareType.Name)[0]
+	sc := c.generateStorageClass(shareType, override)
+
+	if err := c.applyStorageClass(context.TODO(), sc); err != nil {
+		t.Fatalf("applyStorageClass returned error: %v", err)
+	}
+
+	updated, err := kubeClient.StorageV1().StorageClasses().Get(context.TODO(), existing.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get StorageClass: %v", err)
+	}
+	if len(updated.MountOptions) != 2 || updated.MountOptions[0] != "rw" || updated.MountOptions[1] != "hard" {
+		t.Fatalf("expected MountOptions to be updated to [rw hard], got %v", updated.MountOptions)
+	}
+}
@@ -0,0 +1,137 @@
+package manila
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlisters "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// countingRunnable is a Runnable test double that records how many times it
+// was started, so tests can assert csiControllers stay stopped while the
+// controller is disabled.
+type countingRunnable struct {
+	started int
+}
+
+func (r *countingRunnable) Run(ctx context.Context, workers int) {
+	r.started++
+}
+
+func newPlatformTestController(t *testing.T, infra *configv1.Infrastructure, csiControllers ...Runnable) *Controller {
+	t.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if infra != nil {
+		if err := indexer.Add(infra); err != nil {
+			t.Fatalf("failed to seed infrastructure lister: %v", err)
+		}
+	}
+
+	operatorClient := v1helpers.NewFakeOperatorClient(
+		&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed},
+		&operatorv1.OperatorStatus{},
+		nil,
+	)
+
+	return &Controller{
+		operatorClient:           operatorClient,
+		infrastructureLister:     configlisters.NewInfrastructureLister(indexer),
+		eventRecorder:            eventstesting.NewTestingEventRecorder(t),
+		csiControllers:           csiControllers,
+		missingStorageClassSyncs: map[string]int{},
+	}
+}
+
+func TestSyncDisablesOnNonOpenStackPlatform(t *testing.T) {
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: infrastructureName},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+		},
+	}
+	runnable := &countingRunnable{}
+	c := newPlatformTestController(t, infra, runnable)
+
+	// openStackClient is deliberately left nil: if sync() reached
+	// GetShareTypes() instead of short-circuiting on the platform check,
+	// this test would panic on the nil pointer dereference.
+	if err := c.sync(context.TODO(), factory.NewSyncContext("ManilaController", c.eventRecorder)); err != nil {
+		t.Fatalf("sync returned error: %v", err)
+	}
+
+	_, status, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatalf("failed to get operator state: %v", err)
+	}
+	cnd := findCondition(status.Conditions, operatorConditionPrefix+"Disabled")
+	if cnd == nil || cnd.Status != operatorv1.ConditionTrue || cnd.Reason != reasonNotOpenStack {
+		t.Fatalf("expected %sDisabled=True/%s condition, got %+v", operatorConditionPrefix, reasonNotOpenStack, status.Conditions)
+	}
+	if runnable.started != 0 {
+		t.Fatalf("expected csiControllers not to be started on a non-OpenStack cluster, got %d starts", runnable.started)
+	}
+	if c.controllersRunning {
+		t.Fatal("expected controllersRunning to remain false on a non-OpenStack cluster")
+	}
+}
+
+func TestIsOpenStackPlatform(t *testing.T) {
+	tests := []struct {
+		name  string
+		infra *configv1.Infrastructure
+		want  bool
+	}{
+		{
+			name: "openstack",
+			infra: &configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: infrastructureName},
+				Status:     configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.OpenStackPlatformType}},
+			},
+			want: true,
+		},
+		{
+			name: "not openstack",
+			infra: &configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: infrastructureName},
+				Status:     configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType}},
+			},
+			want: false,
+		},
+		{
+			name: "no platform status yet",
+			infra: &configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: infrastructureName},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newPlatformTestController(t, tt.infra)
+			got, err := c.isOpenStackPlatform()
+			if err != nil {
+				t.Fatalf("isOpenStackPlatform returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("isOpenStackPlatform() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func findCondition(conditions []operatorv1.OperatorCondition, condType string) *operatorv1.OperatorCondition {
+	for i := range conditions {
+		if conditions[i].Type == condType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
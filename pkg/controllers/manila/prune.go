@@ -0,0 +1,75 @@
+package manila
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// labelManagedBy marks every StorageClass the operator creates, so
+	// pruneStaleStorageClasses knows which classes on the cluster are its
+	// responsibility.
+	labelManagedBy      = "manila.csi.openshift.io/managed-by"
+	labelManagedByValue = "manila-operator"
+	// labelShareType records which Manila share type a StorageClass was
+	// generated for.
+	labelShareType = "manila.csi.openshift.io/share-type"
+
+	// defaultPruneGracePeriod is the number of consecutive syncs a share
+	// type has to be missing from Manila before its StorageClass is
+	// deleted, tolerating short Manila maintenance windows.
+	defaultPruneGracePeriod = 3
+)
+
+// pruneStaleStorageClasses deletes operator-managed StorageClasses that are
+// not (or no longer) expected, once they have been unexpected for
+// c.pruneGracePeriod consecutive syncs. expectedNames is keyed by
+// StorageClass name, not share type: a class becomes unexpected both when
+// its share type disappears from Manila, and when a ShareTypeConfig rename
+// (a changed or removed NameSuffix) makes syncStorageClasses start
+// generating it under a different name, so a renamed class doesn't leak
+// forever just because its share type is still present. It is opt-in:
+// callers must enable it via SetPruneSettings, because deleting
+// StorageClasses can be disruptive if Manila is merely undergoing
+// maintenance.
+func (c *Controller) pruneStaleStorageClasses(ctx context.Context, expectedNames map[string]bool) error {
+	selector := labels.SelectorFromSet(labels.Set{labelManagedBy: labelManagedByValue})
+	classes, err := c.storageClassLister.List(selector)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sc := range classes {
+		if expectedNames[sc.Name] {
+			delete(c.missingStorageClassSyncs, sc.Name)
+			continue
+		}
+
+		shareType := sc.Labels[labelShareType]
+		c.missingStorageClassSyncs[sc.Name]++
+		missingSyncs := c.missingStorageClassSyncs[sc.Name]
+		if missingSyncs < c.pruneGracePeriod {
+			c.eventRecorder.Warningf("ManilaShareTypePendingPrune",
+				"StorageClass %q (share type %q) is no longer expected (%d/%d syncs); it will be deleted once the grace period elapses",
+				sc.Name, shareType, missingSyncs, c.pruneGracePeriod)
+			continue
+		}
+
+		klog.V(2).Infof("StorageClass %s has been unexpected for %d consecutive syncs, deleting it", sc.Name, missingSyncs)
+		if err := c.deleteStorageClass(ctx, sc.Name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.eventRecorder.Eventf("ManilaShareTypePruned", "Deleted StorageClass %q because share type %q is no longer provided by Manila, or the class was renamed", sc.Name, shareType)
+		delete(c.missingStorageClassSyncs, sc.Name)
+	}
+
+	if len(errs) != 0 {
+		return errors.NewAggregate(errs)
+	}
+	return nil
+}
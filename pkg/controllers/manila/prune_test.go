@@ -0,0 +1,57 @@
+package manila
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/csi-driver-manila-operator/pkg/util"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPruneStaleStorageClassesDeletesRenamedClassAfterGracePeriod(t *testing.T) {
+	stale := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: util.StorageClassNamePrefix + "default-fast",
+			Labels: map[string]string{
+				labelManagedBy: labelManagedByValue,
+				labelShareType: "default",
+			},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(stale)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(stale); err != nil {
+		t.Fatalf("failed to seed storage class lister: %v", err)
+	}
+	c := &Controller{
+		kubeClient:               kubeClient,
+		storageClassLister:       storagelisters.NewStorageClassLister(indexer),
+		eventRecorder:            eventstesting.NewTestingEventRecorder(t),
+		missingStorageClassSyncs: map[string]int{},
+		pruneGracePeriod:         2,
+	}
+	// "default" is still present in Manila, but syncStorageClasses now
+	// expects it under a different (renamed) StorageClass name - the old
+	// one must still eventually be pruned even though its share type never
+	// disappeared.
+	expectedNames := map[string]bool{util.StorageClassNamePrefix + "default-slow": true}
+
+	if err := c.pruneStaleStorageClasses(context.TODO(), expectedNames); err != nil {
+		t.Fatalf("pruneStaleStorageClasses returned error: %v", err)
+	}
+	if _, err := kubeClient.StorageV1().StorageClasses().Get(context.TODO(), stale.Name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected StorageClass %s to still exist within the grace period: %v", stale.Name, err)
+	}
+
+	if err := c.pruneStaleStorageClasses(context.TODO(), expectedNames); err != nil {
+		t.Fatalf("pruneStaleStorageClasses returned error: %v", err)
+	}
+	if _, err := kubeClient.StorageV1().StorageClasses().Get(context.TODO(), stale.Name, metav1.GetOptions{}); err == nil {
+		t.Fatalf("expected renamed StorageClass %s to be deleted once the grace period elapsed", stale.Name)
+	}
+}
@@ -0,0 +1,51 @@
+package manila
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// ShareTypeConfig lets admins customize (or skip) the StorageClass generated
+// for a single Manila share type. It is consumed by generateStorageClass and
+// syncStorageClasses, and is read from the operator spec's
+// observedConfig.shareTypeOverrides field (see config.go).
+type ShareTypeConfig struct {
+	// ShareType is the name of the Manila share type this override applies
+	// to.
+	ShareType string
+	// NameSuffix is appended to the generated StorageClass name, so several
+	// overrides can target the same share type with different classes.
+	NameSuffix string
+	// Default marks the generated StorageClass as the cluster default.
+	Default bool
+	// Exclude prevents the StorageClass this override names (share type plus
+	// NameSuffix) from being generated, and deletes that class if it exists.
+	// Other overrides of the same share type are not affected.
+	Exclude bool
+
+	ReclaimPolicy     *corev1.PersistentVolumeReclaimPolicy
+	VolumeBindingMode *storagev1.VolumeBindingMode
+	MountOptions      []string
+	// Parameters is merged into the default StorageClass parameters,
+	// overriding any key the operator would otherwise set.
+	Parameters map[string]string
+}
+
+// shareTypeOverridesFor returns every ShareTypeConfig configured for the
+// given share type name, in configuration order. Several overrides can
+// target the same share type (e.g. to generate more than one StorageClass
+// for it via distinct NameSuffixes); a share type with no configured
+// override gets a single zero-value ShareTypeConfig, i.e. the default,
+// unsuffixed StorageClass.
+func (c *Controller) shareTypeOverridesFor(shareType string) []ShareTypeConfig {
+	var matches []ShareTypeConfig
+	for _, override := range c.shareTypeOverrides {
+		if override.ShareType == shareType {
+			matches = append(matches, override)
+		}
+	}
+	if len(matches) == 0 {
+		return []ShareTypeConfig{{ShareType: shareType}}
+	}
+	return matches
+}
@@ -0,0 +1,114 @@
+package manila
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
+	"github.com/openshift/csi-driver-manila-operator/pkg/util"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestController(t *testing.T, existing ...*storagev1.StorageClass) (*Controller, *fake.Clientset) {
+	t.Helper()
+
+	objs := make([]runtime.Object, 0, len(existing))
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, sc := range existing {
+		objs = append(objs, sc)
+		if err := indexer.Add(sc); err != nil {
+			t.Fatalf("failed to seed storage class lister: %v", err)
+		}
+	}
+	kubeClient := fake.NewSimpleClientset(objs...)
+
+	return &Controller{
+		kubeClient:               kubeClient,
+		storageClassLister:       storagelisters.NewStorageClassLister(indexer),
+		eventRecorder:            eventstesting.NewTestingEventRecorder(t),
+		missingStorageClassSyncs: map[string]int{},
+		pruneGracePeriod:         defaultPruneGracePeriod,
+	}, kubeClient
+}
+
+func TestGenerateStorageClassMergePrecedence(t *testing.T) {
+	c, _ := newTestController(t)
+	c.compatibilitySettings = map[string]string{
+		"nfs-shareProto":   "NFS",
+		"cephfs-client-id": "compat",
+	}
+	c.shareTypeOverrides = []ShareTypeConfig{
+		{ShareType: "default", Parameters: map[string]string{"cephfs-client-id": "override"}},
+	}
+	shareType := sharetypes.ShareType{Name: "default"}
+
+	overrides := c.shareTypeOverridesFor(shareType.Name)
+	if len(overrides) != 1 {
+		t.Fatalf("expected 1 override, got %d", len(overrides))
+	}
+	sc := c.generateStorageClass(shareType, overrides[0])
+
+	if sc.Parameters["nfs-shareProto"] != "NFS" {
+		t.Errorf("expected compatibility setting to propagate, got %q", sc.Parameters["nfs-shareProto"])
+	}
+	if sc.Parameters["cephfs-client-id"] != "override" {
+		t.Errorf("expected share-type override to win over compatibility setting, got %q", sc.Parameters["cephfs-client-id"])
+	}
+	if sc.Parameters["type"] != "default" {
+		t.Errorf("expected operator default parameter to still be set, got %q", sc.Parameters["type"])
+	}
+}
+
+func TestShareTypeOverridesForMultipleClassesPerShareType(t *testing.T) {
+	c, _ := newTestController(t)
+	c.shareTypeOverrides = []ShareTypeConfig{
+		{ShareType: "default", NameSuffix: "-fast"},
+		{ShareType: "default", NameSuffix: "-slow"},
+	}
+
+	overrides := c.shareTypeOverridesFor("default")
+	if len(overrides) != 2 {
+		t.Fatalf("expected 2 overrides for a share type with two configs, got %d", len(overrides))
+	}
+
+	names := map[string]bool{}
+	for _, o := range overrides {
+		names[storageClassName("default", o)] = true
+	}
+	wantFast := util.StorageClassNamePrefix + "default-fast"
+	wantSlow := util.StorageClassNamePrefix + "default-slow"
+	if !names[wantFast] || !names[wantSlow] {
+		t.Fatalf("expected StorageClass names %q and %q, got %v", wantFast, wantSlow, names)
+	}
+}
+
+func TestShareTypeOverridesForNoConfig(t *testing.T) {
+	c, _ := newTestController(t)
+	overrides := c.shareTypeOverridesFor("default")
+	if len(overrides) != 1 || !reflect.DeepEqual(overrides[0], ShareTypeConfig{ShareType: "default"}) {
+		t.Fatalf("expected a single default override for an unconfigured share type, got %+v", overrides)
+	}
+}
+
+func TestSyncStorageClassesDeletesExcludedShareType(t *testing.T) {
+	existing := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: util.StorageClassNamePrefix + "default"},
+	}
+	c, kubeClient := newTestController(t, existing)
+	c.shareTypeOverrides = []ShareTypeConfig{{ShareType: "default", Exclude: true}}
+
+	if err := c.syncStorageClasses(context.TODO(), []sharetypes.ShareType{{Name: "default"}}); err != nil {
+		t.Fatalf("syncStorageClasses returned error: %v", err)
+	}
+
+	if _, err := kubeClient.StorageV1().StorageClasses().Get(context.TODO(), existing.Name, metav1.GetOptions{}); err == nil {
+		t.Fatalf("expected excluded StorageClass %s to have been deleted", existing.Name)
+	}
+}